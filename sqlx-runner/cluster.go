@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"sync/atomic"
+
+	"github.com/tnextday/pgat/dat"
+)
+
+// Cluster routes reads across a pool of replica connections while writes,
+// Call, and Begin go to a single primary. Embedding *DB means every method
+// *DB exposes (InsertInto, Update, DeleteFrom, Upsert, Call, Begin, ...)
+// is inherited unchanged and stays pinned to the primary; only Select and
+// SelectDoc are overridden to load-balance across replicas.
+type Cluster struct {
+	*DB
+	replicas []*DB
+	next     uint64
+}
+
+// NewCluster opens a primary connection plus one *DB per entry in
+// replicaConnStrings, all using the "postgres" driver, and returns a
+// Cluster that round-robins reads across the replicas. With no replicas
+// configured, reads fall back to the primary.
+func NewCluster(primaryConnString string, replicaConnStrings []string, opts ...Option) *Cluster {
+	primary := NewDBFromString("postgres", primaryConnString, opts...)
+	replicas := make([]*DB, len(replicaConnStrings))
+	for i, connString := range replicaConnStrings {
+		replicas[i] = NewDBFromString("postgres", connString, opts...)
+	}
+	return &Cluster{DB: primary, replicas: replicas}
+}
+
+// replica returns the next replica to read from, round-robin, or the
+// primary if no replicas were configured.
+func (c *Cluster) replica() *DB {
+	if len(c.replicas) == 0 {
+		return c.DB
+	}
+	i := atomic.AddUint64(&c.next, 1)
+	return c.replicas[i%uint64(len(c.replicas))]
+}
+
+// ForceMaster returns a Queryable bound to the primary, for read-your-writes
+// call sites that must observe a write this Cluster just made.
+func (c *Cluster) ForceMaster() *Queryable {
+	return c.DB.Queryable
+}
+
+// Select creates a new SelectBuilder that runs against a replica.
+func (c *Cluster) Select(columns ...string) *dat.SelectBuilder {
+	return c.replica().Select(columns...)
+}
+
+// SelectDoc creates a new SelectDocBuilder that runs against a replica.
+func (c *Cluster) SelectDoc(columns ...string) *dat.SelectDocBuilder {
+	return c.replica().SelectDoc(columns...)
+}
+
+// Close closes the primary and all replica connections, returning the
+// first error encountered, if any.
+func (c *Cluster) Close() error {
+	err := c.DB.Close()
+	for _, r := range c.replicas {
+		if e := r.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,161 @@
+package runner
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/tnextday/pgat/dat"
+)
+
+// ExecContext executes a SQL query with optional arguments, honoring ctx
+// cancellation/deadline when the underlying runner supports it.
+func (q *Queryable) ExecContext(ctx context.Context, cmd string, args ...interface{}) (*dat.Result, error) {
+	execer, ok := q.runner.(sqlx.ExecerContext)
+	if !ok {
+		return q.Exec(cmd, args...)
+	}
+
+	var result sql.Result
+	var err error
+
+	if len(args) == 0 {
+		result, err = execer.ExecContext(ctx, cmd)
+	} else {
+		result, err = execer.ExecContext(ctx, cmd, args...)
+	}
+	if err != nil {
+		return nil, logSQLError(q.logger, err, "ExecContext", cmd, args)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, logSQLError(q.logger, err, "ExecContext", cmd, args)
+	}
+	return &dat.Result{RowsAffected: rowsAffected}, nil
+}
+
+// ExecBuilderContext executes the SQL in builder, honoring ctx cancellation.
+func (q *Queryable) ExecBuilderContext(ctx context.Context, b dat.Builder) error {
+	sql, args, err := b.Interpolate()
+	if err != nil {
+		return err
+	}
+
+	execer, ok := q.runner.(sqlx.ExecerContext)
+	if !ok {
+		return q.ExecBuilder(b)
+	}
+
+	if len(args) == 0 {
+		_, err = execer.ExecContext(ctx, sql)
+	} else {
+		_, err = execer.ExecContext(ctx, sql, args...)
+	}
+	if err != nil {
+		return logSQLError(q.logger, err, "ExecBuilderContext", sql, args)
+	}
+	return nil
+}
+
+// ExecMultiContext executes multiple SQL statements returning the number of
+// statements executed, or the index at which an error or ctx cancellation
+// occurred.
+func (q *Queryable) ExecMultiContext(ctx context.Context, commands ...*dat.Expression) (int, error) {
+	execer, ok := q.runner.(sqlx.ExecerContext)
+	for i, cmd := range commands {
+		if err := ctx.Err(); err != nil {
+			return i, err
+		}
+		var err error
+		if ok {
+			_, err = execer.ExecContext(ctx, cmd.SQL, cmd.Args...)
+		} else {
+			_, err = q.runner.Exec(cmd.SQL, cmd.Args...)
+		}
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(commands), nil
+}
+
+// ExecScriptContext executes a script with multiple statements delimited by
+// a separator ('GO'), aborting the remaining statements as soon as ctx is
+// canceled.
+func (q *Queryable) ExecScriptContext(ctx context.Context, script string, args ...interface{}) error {
+	execer, ok := q.runner.(sqlx.ExecerContext)
+	statements := splitEx(script, q.scriptSeparator())
+	for _, sql := range statements {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "SQL: "+sql)
+		}
+		var err error
+		if ok {
+			_, err = execer.ExecContext(ctx, sql, args...)
+		} else {
+			_, err = q.runner.Exec(sql, args...)
+		}
+		if err != nil {
+			return errors.Wrap(err, "SQL: "+sql)
+		}
+	}
+	return nil
+}
+
+// ctxRunner adapts a database so the Exec/Query calls issued through it
+// honor ctx cancellation/deadline, letting SelectContext/SelectDocContext
+// cancel a long-running SelectDoc/QueryJSON/QueryStructs call. It falls
+// back to the plain call when the wrapped runner doesn't implement the
+// matching sqlx *Context interface (e.g. a driver without context support).
+type ctxRunner struct {
+	database
+	ctx context.Context
+}
+
+func (r ctxRunner) Exec(query string, args ...interface{}) (sql.Result, error) {
+	if execer, ok := r.database.(sqlx.ExecerContext); ok {
+		return execer.ExecContext(r.ctx, query, args...)
+	}
+	return r.database.Exec(query, args...)
+}
+
+func (r ctxRunner) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	if queryer, ok := r.database.(sqlx.QueryerContext); ok {
+		return queryer.QueryContext(r.ctx, query, args...)
+	}
+	return r.database.Query(query, args...)
+}
+
+func (r ctxRunner) Queryx(query string, args ...interface{}) (*sqlx.Rows, error) {
+	if queryer, ok := r.database.(sqlx.QueryerContext); ok {
+		return queryer.QueryxContext(r.ctx, query, args...)
+	}
+	return r.database.Queryx(query, args...)
+}
+
+func (r ctxRunner) QueryRowx(query string, args ...interface{}) *sqlx.Row {
+	if queryer, ok := r.database.(sqlx.QueryerContext); ok {
+		return queryer.QueryRowxContext(r.ctx, query, args...)
+	}
+	return r.database.QueryRowx(query, args...)
+}
+
+// SelectContext creates a new SelectBuilder for the given columns whose
+// eventual QueryStructs/QueryScalar call honors ctx cancellation/deadline,
+// so callers can cancel a long-running read instead of blocking until it
+// finishes.
+func (q *Queryable) SelectContext(ctx context.Context, columns ...string) *dat.SelectBuilder {
+	b := dat.NewSelectBuilder(columns...)
+	b.Execer = NewExecer(ctxRunner{q.queryRunner(), ctx}, b)
+	return b
+}
+
+// SelectDocContext creates a new SelectDocBuilder for the given columns
+// whose eventual QueryJSON call honors ctx cancellation/deadline.
+func (q *Queryable) SelectDocContext(ctx context.Context, columns ...string) *dat.SelectDocBuilder {
+	b := dat.NewSelectDocBuilder(columns...)
+	b.Execer = NewExecer(ctxRunner{q.queryRunner(), ctx}, b)
+	return b
+}
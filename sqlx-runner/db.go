@@ -2,7 +2,7 @@ package runner
 
 import (
 	"database/sql"
-	"log"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/tnextday/pgat/dat"
@@ -15,9 +15,55 @@ type DB struct {
 	Version int64
 }
 
-// Close closes the DB releasing any open resources. Passthrough
-// to sql.DB.Close()
+// Option configures a *DB at construction time, e.g. NewDB(db, "postgres",
+// WithLogger(myLogger), WithLogQueriesThreshold(200*time.Millisecond)).
+type Option func(*DB)
+
+// WithLogger attaches a Logger to the DB (and any Tx it later opens),
+// replacing the default stderr logger. Use this to route pgat's internal
+// diagnostics through a logr/zap/zerolog adapter with request-scoped
+// fields (db prefix, trace id).
+func WithLogger(logger Logger) Option {
+	return func(db *DB) {
+		db.Queryable.logger = logger
+	}
+}
+
+// WithLogQueriesThreshold causes Queryable.Exec and Queryable.ExecBuilder
+// calls that take at least d to be logged at Info level with their SQL,
+// args, and elapsed time. Other query paths are not timed.
+func WithLogQueriesThreshold(d time.Duration) Option {
+	return func(db *DB) {
+		db.Queryable.logQueriesThreshold = d
+	}
+}
+
+// WithStmtCache enables an LRU cache of up to capacity prepared statements,
+// keyed by each query's placeholder SQL template, so repeated calls to the
+// same query shape reuse a *sqlx.Stmt instead of re-parsing it on every
+// Exec. Callers opt individual query chains in with Queryable.Cached().
+func WithStmtCache(capacity int) Option {
+	return func(db *DB) {
+		db.Queryable.cache = newStmtCache(capacity)
+	}
+}
+
+// StmtCacheStats returns the prepared-statement cache's hit/miss/eviction
+// counters, or a zero StmtCacheStats if WithStmtCache was not used.
+func (db *DB) StmtCacheStats() StmtCacheStats {
+	if db.cache == nil {
+		return StmtCacheStats{}
+	}
+	return db.cache.Stats()
+}
+
+// Close closes the DB releasing any open resources, including any
+// prepared-statement cache enabled with WithStmtCache. Passthrough to
+// sql.DB.Close().
 func (db *DB) Close() error {
+	if db.cache != nil {
+		db.cache.Close()
+	}
 	return db.DB.Close()
 }
 
@@ -28,9 +74,15 @@ func (db *DB) Loose() *DB {
 	unsafe := db.DB.Unsafe()
 
 	return &DB{
-		DB:        unsafe,
-		Queryable: &Queryable{unsafe},
-		Version:   db.Version,
+		DB: unsafe,
+		Queryable: &Queryable{
+			runner:              unsafe,
+			logger:              db.logger,
+			logQueriesThreshold: db.logQueriesThreshold,
+			dialect:             db.dialect,
+			cache:               db.cache,
+		},
+		Version: db.Version,
 	}
 }
 
@@ -55,58 +107,79 @@ func pgMustNotAllowEscapeSequence(conn *DB) {
 	}
 
 	if standardConformingStrings != "on" {
-		log.Fatalf("Database allows escape sequences. Cannot be used with interpolation. "+
-			"standard_conforming_strings=%q\n"+
+		conn.logger.Fatal("Database allows escape sequences. Cannot be used with interpolation. "+
 			"See http://www.postgresql.org/docs/9.3/interactive/sql-syntax-lexical.html#SQL-SYNTAX-STRINGS-ESCAPE",
-			standardConformingStrings)
+			"standard_conforming_strings", standardConformingStrings)
 	}
 }
 
-func pgMustSetVersion(db *DB) {
+func mustSetVersion(db *DB) {
 	err := db.
-		SQL("SHOW server_version_num").
+		SQL(db.dialect.VersionQuery()).
 		QueryScalar(&db.Version)
 	if err != nil {
-		Logger.Fatal("Could not query Postgres version")
+		db.logger.Fatal("Could not query " + db.dialect.Name() + " version")
 		return
 	}
 }
 
-// NewDB instantiates a Connection for a given database/sql connection
-func NewDB(db *sql.DB, driverName string) *DB {
+// NewDB instantiates a Connection for a given database/sql connection. The
+// driver name selects a registered Dialect (see RegisterDialect); pgat ships
+// "postgres", "mysql", and "sqlite3" out of the box. Pass options such as
+// WithLogger or WithLogQueriesThreshold to customize logging; with none
+// given it logs to stderr and never treats queries as slow.
+func NewDB(db *sql.DB, driverName string, opts ...Option) *DB {
+	dialect, ok := dialectFor(driverName)
+	if !ok {
+		panic("Unsupported driver: " + driverName)
+	}
+
 	database := sqlx.NewDb(db, driverName)
 
-	conn := &DB{DB: database, Queryable: &Queryable{database}}
-	if driverName == "postgres" {
-		pgMustNotAllowEscapeSequence(conn)
-		pgMustSetVersion(conn)
-		if dat.Strict {
-			conn.SQL("SET client_min_messages to 'DEBUG';")
-		}
-	} else {
-		panic("Unsupported driver: " + driverName)
+	conn := &DB{DB: database, Queryable: &Queryable{runner: database, logger: defaultLogger, dialect: dialect}}
+	for _, opt := range opts {
+		opt(conn)
+	}
+	if err := dialect.ValidateInterpolation(conn); err != nil {
+		panic(err)
+	}
+	mustSetVersion(conn)
+	if dialect.Name() == "postgres" && dat.Strict {
+		conn.SQL("SET client_min_messages to 'DEBUG';")
 	}
 	return conn
 }
 
 // NewDBFromString instantiates a Connection from a given driver
 // and connection string.
-func NewDBFromString(driver string, connectionString string) *DB {
+func NewDBFromString(driver string, connectionString string, opts ...Option) *DB {
 	db, err := sql.Open(driver, connectionString)
 	if err != nil {
-		Logger.Fatal("Database error ", "err", err)
+		defaultLogger.Fatal("Database error ", "err", err)
+		return nil
 	}
 	err = db.Ping()
 	if err != nil {
-		Logger.Fatal("Could not ping database", "err", err)
+		defaultLogger.Fatal("Could not ping database", "err", err)
+		return nil
 	}
-	return NewDB(db, driver)
+	return NewDB(db, driver, opts...)
 }
 
 // NewDBFromSqlx creates a new Connection object from existing Sqlx.DB.
-func NewDBFromSqlx(dbx *sqlx.DB) *DB {
-	conn := &DB{DB: dbx, Queryable: &Queryable{dbx}}
-	pgMustNotAllowEscapeSequence(conn)
-	pgMustSetVersion(conn)
+func NewDBFromSqlx(dbx *sqlx.DB, opts ...Option) *DB {
+	dialect, ok := dialectFor(dbx.DriverName())
+	if !ok {
+		panic("Unsupported driver: " + dbx.DriverName())
+	}
+
+	conn := &DB{DB: dbx, Queryable: &Queryable{runner: dbx, logger: defaultLogger, dialect: dialect}}
+	for _, opt := range opts {
+		opt(conn)
+	}
+	if err := dialect.ValidateInterpolation(conn); err != nil {
+		panic(err)
+	}
+	mustSetVersion(conn)
 	return conn
 }
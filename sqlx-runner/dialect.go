@@ -0,0 +1,127 @@
+package runner
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// Dialect captures the SQL-generation and bootstrap differences between
+// database backends, so DB and the Queryable builder factories don't need
+// to hard-code Postgres behavior.
+type Dialect interface {
+	// Name is the driver name this Dialect is registered under (e.g.
+	// "postgres", "mysql", "sqlite3").
+	Name() string
+	// VersionQuery returns a SQL statement that selects a single integer
+	// version, used to populate DB.Version on connect.
+	VersionQuery() string
+	// ValidateInterpolation runs any backend-specific checks needed before
+	// dat.EnableInterpolation can be trusted (e.g. Postgres's
+	// standard_conforming_strings check). It is a no-op for dialects with
+	// nothing to validate.
+	ValidateInterpolation(db *DB) error
+	// QuoteIdent quotes name as an identifier for this dialect.
+	QuoteIdent(name string) string
+	// Placeholder returns the positional bind placeholder for the i'th
+	// argument (1-based), e.g. "$1" for Postgres or "?" for MySQL/SQLite.
+	Placeholder(i int) string
+	// SupportsReturning reports whether INSERT/UPDATE/DELETE ... RETURNING
+	// is available, gating InsertInto/Upsert/Insect's use of it.
+	SupportsReturning() bool
+	// SupportsUpsert reports whether an atomic upsert clause (e.g.
+	// Postgres's ON CONFLICT) is available.
+	SupportsUpsert() bool
+	// ScriptSeparator is the regex ExecScript/ExecScriptContext split
+	// multi-statement scripts on.
+	ScriptSeparator() *regexp.Regexp
+}
+
+var dialects = map[string]Dialect{}
+
+// RegisterDialect makes a Dialect available to NewDB under driverName.
+// Drivers register themselves from an init() func, mirroring how
+// database/sql drivers register themselves with sql.Register.
+func RegisterDialect(driverName string, d Dialect) {
+	dialects[driverName] = d
+}
+
+func dialectFor(driverName string) (Dialect, bool) {
+	d, ok := dialects[driverName]
+	return d, ok
+}
+
+func init() {
+	RegisterDialect("postgres", postgresDialect{})
+	RegisterDialect("mysql", mysqlDialect{})
+	RegisterDialect("sqlite3", sqliteDialect{})
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string         { return "postgres" }
+func (postgresDialect) VersionQuery() string { return "SHOW server_version_num" }
+
+func (postgresDialect) ValidateInterpolation(db *DB) error {
+	pgMustNotAllowEscapeSequence(db)
+	return nil
+}
+
+func (postgresDialect) QuoteIdent(name string) string { return `"` + name + `"` }
+func (postgresDialect) Placeholder(i int) string      { return "$" + strconv.Itoa(i) }
+func (postgresDialect) SupportsReturning() bool       { return true }
+func (postgresDialect) SupportsUpsert() bool          { return true }
+func (postgresDialect) ScriptSeparator() *regexp.Regexp {
+	return reScriptSeparator
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string                       { return "mysql" }
+// VersionQuery turns VERSION() (e.g. "8.0.32-log") into
+// major*10000 + minor*100 + patch (80032).
+func (mysqlDialect) VersionQuery() string {
+	return "SELECT CAST(SUBSTRING_INDEX(VERSION(), '.', 1) AS UNSIGNED) * 10000" +
+		" + CAST(SUBSTRING_INDEX(SUBSTRING_INDEX(VERSION(), '.', 2), '.', -1) AS UNSIGNED) * 100" +
+		" + CAST(SUBSTRING_INDEX(SUBSTRING_INDEX(VERSION(), '.', 3), '.', -1) AS UNSIGNED)"
+}
+func (mysqlDialect) ValidateInterpolation(db *DB) error { return nil }
+func (mysqlDialect) QuoteIdent(name string) string      { return "`" + name + "`" }
+func (mysqlDialect) Placeholder(i int) string           { return "?" }
+
+// SupportsReturning is false: MySQL gained RETURNING only on MariaDB, not
+// upstream MySQL, so InsertInto/Upsert must not rely on it here.
+func (mysqlDialect) SupportsReturning() bool { return false }
+
+// SupportsUpsert is true via "INSERT ... ON DUPLICATE KEY UPDATE", though
+// the SQL shape differs from Postgres's ON CONFLICT and must be generated
+// accordingly by the builder.
+func (mysqlDialect) SupportsUpsert() bool { return true }
+func (mysqlDialect) ScriptSeparator() *regexp.Regexp {
+	return reScriptSeparator
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string                       { return "sqlite3" }
+func (sqliteDialect) Name() string { return "sqlite3" }
+
+// VersionQuery turns sqlite_version() (e.g. "3.42.0") into
+// major*1000000 + minor*1000 + patch (3042000), relying on SQLite's CAST of
+// text to INTEGER keeping the longest integer prefix.
+func (sqliteDialect) VersionQuery() string {
+	return "SELECT CAST(sqlite_version() AS INTEGER) * 1000000" +
+		" + CAST(substr(sqlite_version(), instr(sqlite_version(), '.') + 1) AS INTEGER) * 1000" +
+		" + CAST(substr(substr(sqlite_version(), instr(sqlite_version(), '.') + 1)," +
+		" instr(substr(sqlite_version(), instr(sqlite_version(), '.') + 1), '.') + 1) AS INTEGER)"
+}
+func (sqliteDialect) ValidateInterpolation(db *DB) error { return nil }
+func (sqliteDialect) QuoteIdent(name string) string      { return `"` + name + `"` }
+func (sqliteDialect) Placeholder(i int) string           { return "?" }
+
+// SupportsReturning is true for SQLite 3.35+; callers on older SQLite
+// builds should avoid RETURNING-dependent builder calls.
+func (sqliteDialect) SupportsReturning() bool { return true }
+func (sqliteDialect) SupportsUpsert() bool    { return true }
+func (sqliteDialect) ScriptSeparator() *regexp.Regexp {
+	return reScriptSeparator
+}
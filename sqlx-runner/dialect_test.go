@@ -0,0 +1,49 @@
+package runner
+
+import "testing"
+
+func TestDialectCapabilities(t *testing.T) {
+	cases := []struct {
+		name              string
+		dialect           Dialect
+		supportsReturning bool
+		supportsUpsert    bool
+		placeholder1      string
+		quotedIdent       string
+	}{
+		{"postgres", postgresDialect{}, true, true, "$1", `"col"`},
+		{"mysql", mysqlDialect{}, false, true, "?", "`col`"},
+		{"sqlite3", sqliteDialect{}, true, true, "?", `"col"`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.dialect.Name(); got != c.name {
+				t.Errorf("Name() = %q, want %q", got, c.name)
+			}
+			if got := c.dialect.SupportsReturning(); got != c.supportsReturning {
+				t.Errorf("SupportsReturning() = %v, want %v", got, c.supportsReturning)
+			}
+			if got := c.dialect.SupportsUpsert(); got != c.supportsUpsert {
+				t.Errorf("SupportsUpsert() = %v, want %v", got, c.supportsUpsert)
+			}
+			if got := c.dialect.Placeholder(1); got != c.placeholder1 {
+				t.Errorf("Placeholder(1) = %q, want %q", got, c.placeholder1)
+			}
+			if got := c.dialect.QuoteIdent("col"); got != c.quotedIdent {
+				t.Errorf("QuoteIdent(%q) = %q, want %q", "col", got, c.quotedIdent)
+			}
+		})
+	}
+}
+
+func TestDialectFor(t *testing.T) {
+	for _, name := range []string{"postgres", "mysql", "sqlite3"} {
+		if _, ok := dialectFor(name); !ok {
+			t.Errorf("dialectFor(%q) not registered", name)
+		}
+	}
+	if _, ok := dialectFor("unknown-driver"); ok {
+		t.Error(`dialectFor("unknown-driver") unexpectedly registered`)
+	}
+}
@@ -0,0 +1,58 @@
+package runner
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+// Logger is the structured logging interface pgat routes its internal
+// diagnostics through: transaction lifecycle events, SQL errors, and fatal
+// bootstrap failures. Implementations can forward Debug/Info/Error/Fatal
+// to logr, zap, zerolog, or any other key-value structured logger, adding
+// request-scoped fields (db name, trace id) as needed.
+type Logger interface {
+	Debug(msg string, keyvals ...interface{})
+	Info(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{}) error
+	Fatal(msg string, keyvals ...interface{})
+}
+
+// defaultLogger is used by any DB/Tx/Queryable that was not given a Logger
+// via WithLogger, and by package-level helpers that run before a *DB
+// exists (e.g. while a connection is still being opened).
+var defaultLogger Logger = NewStdLogger()
+
+// NewStdLogger returns a Logger that writes to os.Stderr via the standard
+// library log package. It is pgat's default when no WithLogger option is
+// supplied to NewDB.
+func NewStdLogger() Logger {
+	return &stdLogger{l: log.New(os.Stderr, "pgat ", log.LstdFlags)}
+}
+
+type stdLogger struct {
+	l *log.Logger
+}
+
+func (s *stdLogger) Debug(msg string, keyvals ...interface{}) { s.print("DEBUG", msg, keyvals) }
+func (s *stdLogger) Info(msg string, keyvals ...interface{})  { s.print("INFO", msg, keyvals) }
+
+func (s *stdLogger) Fatal(msg string, keyvals ...interface{}) {
+	s.print("FATAL", msg, keyvals)
+	os.Exit(1)
+}
+
+func (s *stdLogger) Error(msg string, keyvals ...interface{}) error {
+	s.print("ERROR", msg, keyvals)
+	return fmt.Errorf("%s %v", msg, keyvals)
+}
+
+func (s *stdLogger) print(level, msg string, keyvals []interface{}) {
+	s.l.Println(append([]interface{}{level, msg}, keyvals...)...)
+}
+
+// logSQLError logs a SQL execution failure through logger and returns the
+// error logger.Error produces for the caller to propagate.
+func logSQLError(logger Logger, err error, op string, sql string, args []interface{}) error {
+	return logger.Error(op, "err", err, "sql", sql, "args", args)
+}
@@ -0,0 +1,336 @@
+// Package migrate is a small goose-style migration runner built on top of
+// runner.Queryable.ExecScript: numbered .sql files with "-- +pgat Up" /
+// "-- +pgat Down" annotated sections, tracked in a schema_migrations table.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tnextday/pgat/sqlx-runner"
+)
+
+// advisoryLockKey is the fixed pg_advisory_lock key Migrate takes for the
+// duration of a run, so concurrent app instances never double-apply a
+// migration. 0x70676174 spells "pgat" in hex.
+const advisoryLockKey = 0x70676174
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_(.+)\.sql$`)
+
+var (
+	upMarker   = regexp.MustCompile(`(?m)^--\s*\+pgat\s+Up\b.*$`)
+	downMarker = regexp.MustCompile(`(?m)^--\s*\+pgat\s+Down\b.*$`)
+)
+
+// Migration is one numbered, reversible schema change, loaded from a
+// "<version>_<name>.sql" file containing "-- +pgat Up" and
+// "-- +pgat Down" annotated sections.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status describes whether a Migration has been applied, and when.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type schemaMigrationRow struct {
+	Version   int64     `db:"version"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+// Migrate runs numbered SQL migrations from an fs.FS (including an
+// embedded one via //go:embed) against db, tracking applied versions in a
+// schema_migrations table and serializing concurrent runs with a Postgres
+// advisory lock.
+type Migrate struct {
+	db *runner.DB
+}
+
+// New returns a Migrate that applies migrations to db.
+func New(db *runner.DB) *Migrate {
+	return &Migrate{db: db}
+}
+
+// Up applies every migration in dir whose version is not yet recorded in
+// schema_migrations, in ascending order, each inside its own Tx with
+// AutoRollback on error.
+func (m *Migrate) Up(ctx context.Context, dir fs.FS) error {
+	return m.withLock(ctx, func() error {
+		if err := m.ensureSchemaTable(ctx); err != nil {
+			return err
+		}
+		migrations, err := loadMigrations(dir)
+		if err != nil {
+			return err
+		}
+		applied, err := m.appliedVersions(ctx)
+		if err != nil {
+			return err
+		}
+		for _, mg := range migrations {
+			if applied[mg.Version] {
+				continue
+			}
+			if err := m.applyUp(ctx, mg); err != nil {
+				return fmt.Errorf("migrate up %d_%s: %w", mg.Version, mg.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Down rolls back the n most recently applied migrations, most recent
+// first, each inside its own Tx with AutoRollback on error.
+func (m *Migrate) Down(ctx context.Context, dir fs.FS, n int) error {
+	return m.withLock(ctx, func() error {
+		if err := m.ensureSchemaTable(ctx); err != nil {
+			return err
+		}
+		migrations, err := loadMigrations(dir)
+		if err != nil {
+			return err
+		}
+		byVersion := make(map[int64]Migration, len(migrations))
+		for _, mg := range migrations {
+			byVersion[mg.Version] = mg
+		}
+
+		versions, err := m.appliedVersionsDesc(ctx)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			n = 0
+		}
+		if n > len(versions) {
+			n = len(versions)
+		}
+		for _, version := range versions[:n] {
+			mg, ok := byVersion[version]
+			if !ok {
+				return fmt.Errorf("migrate down: no migration file for applied version %d", version)
+			}
+			if err := m.applyDown(ctx, mg); err != nil {
+				return fmt.Errorf("migrate down %d_%s: %w", mg.Version, mg.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Status reports every migration found in dir alongside whether (and when)
+// it has been applied.
+func (m *Migrate) Status(ctx context.Context, dir fs.FS) ([]Status, error) {
+	if err := m.ensureSchemaTable(ctx); err != nil {
+		return nil, err
+	}
+	migrations, err := loadMigrations(dir)
+	if err != nil {
+		return nil, err
+	}
+	appliedAt, err := m.appliedAtByVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(migrations))
+	for i, mg := range migrations {
+		at, ok := appliedAt[mg.Version]
+		statuses[i] = Status{Migration: mg, Applied: ok, AppliedAt: at}
+	}
+	return statuses, nil
+}
+
+func (m *Migrate) ensureSchemaTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version bigint PRIMARY KEY,
+	applied_at timestamptz NOT NULL DEFAULT now()
+)`)
+	return err
+}
+
+func (m *Migrate) applyUp(ctx context.Context, mg Migration) error {
+	tx, err := m.db.BeginTxContext(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.AutoRollback()
+
+	if err := tx.ExecScriptContext(ctx, mg.Up); err != nil {
+		return err
+	}
+	if _, err := tx.SQL("INSERT INTO schema_migrations (version) VALUES ($1)", mg.Version).Exec(); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Migrate) applyDown(ctx context.Context, mg Migration) error {
+	tx, err := m.db.BeginTxContext(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.AutoRollback()
+
+	if err := tx.ExecScriptContext(ctx, mg.Down); err != nil {
+		return err
+	}
+	if _, err := tx.SQL("DELETE FROM schema_migrations WHERE version = $1", mg.Version).Exec(); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Migrate) appliedRows(ctx context.Context) ([]schemaMigrationRow, error) {
+	var rows []schemaMigrationRow
+	err := m.db.
+		SelectContext(ctx, "version", "applied_at").
+		From("schema_migrations").
+		OrderBy("version").
+		QueryStructs(&rows)
+	return rows, err
+}
+
+func (m *Migrate) appliedVersions(ctx context.Context) (map[int64]bool, error) {
+	rows, err := m.appliedRows(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]bool, len(rows))
+	for _, r := range rows {
+		applied[r.Version] = true
+	}
+	return applied, nil
+}
+
+func (m *Migrate) appliedVersionsDesc(ctx context.Context) ([]int64, error) {
+	rows, err := m.appliedRows(ctx)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]int64, len(rows))
+	for i, r := range rows {
+		versions[i] = r.Version
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+	return versions, nil
+}
+
+func (m *Migrate) appliedAtByVersion(ctx context.Context) (map[int64]time.Time, error) {
+	rows, err := m.appliedRows(ctx)
+	if err != nil {
+		return nil, err
+	}
+	at := make(map[int64]time.Time, len(rows))
+	for _, r := range rows {
+		at[r.Version] = r.AppliedAt
+	}
+	return at, nil
+}
+
+// withLock holds a Postgres session-level advisory lock for the duration
+// of fn, so two app instances running migrations concurrently serialize
+// instead of racing. It takes a connection of its own, separate from the
+// ones fn's Tx calls acquire from the same *DB, so the pool backing db
+// must allow at least 2 open connections.
+func (m *Migrate) withLock(ctx context.Context, fn func() error) error {
+	conn, err := m.db.DB.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", advisoryLockKey); err != nil {
+		return fmt.Errorf("migrate: acquire advisory lock: %w", err)
+	}
+	// Unlock with a context of its own: if ctx is already canceled/expired
+	// by the time fn returns, the ExecContext below must still run so the
+	// lock isn't left held on the connection Close returns to the pool.
+	defer func() {
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if _, err := conn.ExecContext(unlockCtx, "SELECT pg_advisory_unlock($1)", advisoryLockKey); err != nil {
+			m.db.Logger().Error("migrate: release advisory lock", "err", err)
+		}
+	}()
+
+	return fn()
+}
+
+// loadMigrations reads every "<version>_<name>.sql" file in dir and
+// returns them sorted by ascending version.
+func loadMigrations(dir fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(dir, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := migrationFileRe.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migrate: invalid version in %s: %w", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(dir, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		up, down, err := splitUpDown(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("migrate: %s: %w", entry.Name(), err)
+		}
+
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    match[2],
+			Up:      up,
+			Down:    down,
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// splitUpDown splits a migration file's content on its "-- +pgat Up" /
+// "-- +pgat Down" annotations, in whichever order they appear.
+func splitUpDown(content string) (up string, down string, err error) {
+	upLoc := upMarker.FindStringIndex(content)
+	downLoc := downMarker.FindStringIndex(content)
+	if upLoc == nil {
+		return "", "", fmt.Errorf("missing '-- +pgat Up' annotation")
+	}
+	if downLoc == nil {
+		return "", "", fmt.Errorf("missing '-- +pgat Down' annotation")
+	}
+
+	if upLoc[0] < downLoc[0] {
+		up = strings.TrimSpace(content[upLoc[1]:downLoc[0]])
+		down = strings.TrimSpace(content[downLoc[1]:])
+	} else {
+		down = strings.TrimSpace(content[downLoc[1]:upLoc[0]])
+		up = strings.TrimSpace(content[upLoc[1]:])
+	}
+	return up, down, nil
+}
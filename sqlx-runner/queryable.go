@@ -1,9 +1,11 @@
 package runner
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"regexp"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -14,16 +16,75 @@ import (
 // Queryable is an object that can be queried.
 type Queryable struct {
 	runner database
+	// logger receives transaction-lifecycle and SQL-error diagnostics for
+	// this Queryable. Set via WithLogger on the owning DB; defaults to
+	// defaultLogger.
+	logger Logger
+	// logQueriesThreshold, when non-zero, causes Exec and ExecBuilder calls
+	// that take at least this long to be logged at Info level with their
+	// SQL, args, and elapsed time. Other query paths (builder Execers, the
+	// *Context methods, ExecMulti, ExecScript) are not timed. Set via
+	// WithLogQueriesThreshold on the owning DB.
+	logQueriesThreshold time.Duration
+	// dialect describes the backend this Queryable talks to, letting
+	// builder factories gate dialect-specific SQL (RETURNING, ON CONFLICT).
+	// Populated from the driver name passed to NewDB.
+	dialect Dialect
+	// cache is the prepared-statement cache shared by every Queryable
+	// derived from the same DB. Set via WithStmtCache; nil means no
+	// caching. cached is per-Queryable: only chains built off Cached()
+	// actually consult it.
+	cache  *stmtCache
+	cached bool
 }
 
-// WrapSqlxExt converts a sqlx.Ext to a *Queryable
+// Cached returns a Queryable bound to the same runner that executes
+// through the prepared-statement cache configured with WithStmtCache,
+// keyed by the query's placeholder SQL template, instead of re-parsing
+// the SQL on every ExecBuilder or Select(...).QueryStructs/SelectDoc(...).
+// QueryJSON call. Exec and the builders from InsertInto, Update, DeleteFrom,
+// Upsert, Insect, Call and SQL do not use the cache. It's a no-op when no cache is configured, or when the
+// underlying runner isn't a *sqlx.DB (e.g. inside a Tx, where a prepared
+// statement can't outlive the transaction).
+func (q *Queryable) Cached() *Queryable {
+	cached := *q
+	cached.cached = true
+	return &cached
+}
+
+// WrapSqlxExt converts a sqlx.Ext to a *Queryable. The returned Queryable
+// defaults to postgresDialect, matching WrapSqlxTx, since sqlx.Ext exposes
+// no driver name to infer a dialect from.
 func WrapSqlxExt(e sqlx.Ext) (*Queryable, error) {
 	switch e := e.(type) {
 	default:
 		return nil, dat.NewError(fmt.Sprintf("unexpected type %T", e))
 	case database:
-		return &Queryable{e}, nil
+		return &Queryable{runner: e, logger: defaultLogger, dialect: postgresDialect{}}, nil
+	}
+}
+
+// Dialect returns the backend this Queryable talks to, so builder factories
+// (and callers assembling raw SQL) can gate dialect-specific features such
+// as RETURNING or ON CONFLICT.
+func (q *Queryable) Dialect() Dialect {
+	return q.dialect
+}
+
+// Logger returns the Logger this Queryable routes its diagnostics through,
+// so callers building on top of pgat (e.g. the migrate package) can log
+// through the same sink instead of falling back to defaultLogger.
+func (q *Queryable) Logger() Logger {
+	return q.logger
+}
+
+// logSlowQuery logs cmd/args at Info level if elapsed has crossed
+// q.logQueriesThreshold.
+func (q *Queryable) logSlowQuery(op string, cmd string, args []interface{}, elapsed time.Duration) {
+	if q.logQueriesThreshold <= 0 || elapsed < q.logQueriesThreshold {
+		return
 	}
+	q.logger.Info("slow query", "op", op, "sql", cmd, "args", args, "elapsed", elapsed)
 }
 
 // SplitEx splits a string using a regex
@@ -40,9 +101,19 @@ func splitEx(text string, reg *regexp.Regexp) []string {
 	return result
 }
 
+// scriptSeparator returns the dialect's script separator regex, falling
+// back to the Postgres default when no dialect is set (e.g. a zero-value
+// Queryable).
+func (q *Queryable) scriptSeparator() *regexp.Regexp {
+	if q.dialect != nil {
+		return q.dialect.ScriptSeparator()
+	}
+	return reScriptSeparator
+}
+
 // ExecScript executes a script with multiple statements delimited by a separator ('GO')
 func (q *Queryable) ExecScript(script string, args ...interface{}) error {
-	statements := splitEx(script, reScriptSeparator)
+	statements := splitEx(script, q.scriptSeparator())
 	for _, sql := range statements {
 		_, err := q.runner.Exec(sql, args...)
 		if err != nil {
@@ -71,17 +142,19 @@ func (q *Queryable) Exec(cmd string, args ...interface{}) (*dat.Result, error) {
 	var result sql.Result
 	var err error
 
+	start := time.Now()
 	if len(args) == 0 {
 		result, err = q.runner.Exec(cmd)
 	} else {
 		result, err = q.runner.Exec(cmd, args...)
 	}
+	q.logSlowQuery("Exec", cmd, args, time.Since(start))
 	if err != nil {
-		return nil, logSQLError(err, "Exec", cmd, args)
+		return nil, logSQLError(q.logger, err, "Exec", cmd, args)
 	}
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return nil, logSQLError(err, "Exec", cmd, args)
+		return nil, logSQLError(q.logger, err, "Exec", cmd, args)
 	}
 	return &dat.Result{RowsAffected: rowsAffected}, nil
 }
@@ -93,17 +166,158 @@ func (q *Queryable) ExecBuilder(b dat.Builder) error {
 		return err
 	}
 
-	if len(args) == 0 {
+	start := time.Now()
+	if q.cached && q.cache != nil {
+		err = q.execCached(sql, args)
+	} else if len(args) == 0 {
 		_, err = q.runner.Exec(sql)
 	} else {
 		_, err = q.runner.Exec(sql, args...)
 	}
+	q.logSlowQuery("ExecBuilder", sql, args, time.Since(start))
 	if err != nil {
-		return logSQLError(err, "ExecBuilder", sql, args)
+		return logSQLError(q.logger, err, "ExecBuilder", sql, args)
 	}
 	return nil
 }
 
+// execCached runs sql/args through q.cache, preparing and caching the
+// statement against the underlying *sqlx.DB on first use. Falls back to a
+// plain Exec when the runner isn't a *sqlx.DB.
+func (q *Queryable) execCached(sql string, args []interface{}) error {
+	db, ok := q.runner.(*sqlx.DB)
+	if !ok {
+		_, err := q.runner.Exec(sql, args...)
+		return err
+	}
+
+	stmt, err := q.cache.getOrPrepare(db, sql)
+	if err != nil {
+		return err
+	}
+	if _, err := stmt.Exec(args...); err != nil {
+		q.cache.invalidate(sql)
+		return err
+	}
+	return nil
+}
+
+// cachedRunner adapts a *sqlx.DB so Exec/Query/Queryx/QueryRowx calls run
+// through a prepared-statement cache instead of being re-parsed on every
+// call. It's the read-path analogue of execCached, used to back the Execer
+// that Select/SelectDoc hand their builder so a repeated
+// Select(...).Where(...).QueryStructs(...) shape reuses its *sqlx.Stmt.
+type cachedRunner struct {
+	*sqlx.DB
+	cache *stmtCache
+}
+
+func (r cachedRunner) Exec(query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := r.cache.getOrPrepare(r.DB, query)
+	if err != nil {
+		return nil, err
+	}
+	result, err := stmt.Exec(args...)
+	if err != nil {
+		r.cache.invalidate(query)
+	}
+	return result, err
+}
+
+func (r cachedRunner) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, err := r.cache.getOrPrepare(r.DB, query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		r.cache.invalidate(query)
+	}
+	return rows, err
+}
+
+func (r cachedRunner) Queryx(query string, args ...interface{}) (*sqlx.Rows, error) {
+	stmt, err := r.cache.getOrPrepare(r.DB, query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Queryx(args...)
+	if err != nil {
+		r.cache.invalidate(query)
+	}
+	return rows, err
+}
+
+func (r cachedRunner) QueryRowx(query string, args ...interface{}) *sqlx.Row {
+	stmt, err := r.cache.getOrPrepare(r.DB, query)
+	if err != nil {
+		return r.DB.QueryRowx(query, args...)
+	}
+	return stmt.QueryRowx(args...)
+}
+
+// ExecContext, QueryContext, QueryxContext, and QueryRowxContext let
+// cachedRunner satisfy sqlx.ExecerContext/QueryerContext, so ctxRunner wraps
+// it with the *Context sqlx.Stmt calls instead of falling back to the
+// blocking ones above, letting a Cached() chain stay cancelable.
+func (r cachedRunner) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := r.cache.getOrPrepare(r.DB, query)
+	if err != nil {
+		return nil, err
+	}
+	result, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		r.cache.invalidate(query)
+	}
+	return result, err
+}
+
+func (r cachedRunner) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, err := r.cache.getOrPrepare(r.DB, query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		r.cache.invalidate(query)
+	}
+	return rows, err
+}
+
+func (r cachedRunner) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	stmt, err := r.cache.getOrPrepare(r.DB, query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryxContext(ctx, args...)
+	if err != nil {
+		r.cache.invalidate(query)
+	}
+	return rows, err
+}
+
+func (r cachedRunner) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	stmt, err := r.cache.getOrPrepare(r.DB, query)
+	if err != nil {
+		return r.DB.QueryRowxContext(ctx, query, args...)
+	}
+	return stmt.QueryRowxContext(ctx, args...)
+}
+
+// queryRunner returns the database Select/SelectDoc build their Execer
+// from: a cache-backed wrapper when Cached() was called and the runner is
+// a *sqlx.DB the cache can prepare statements against, the plain runner
+// otherwise (e.g. inside a Tx, where a prepared statement can't outlive
+// the transaction).
+func (q *Queryable) queryRunner() database {
+	if q.cached && q.cache != nil {
+		if db, ok := q.runner.(*sqlx.DB); ok {
+			return cachedRunner{DB: db, cache: q.cache}
+		}
+	}
+	return q.runner
+}
+
 // ExecMulti executes multiple SQL statements returning the number of
 // statements executed, or the index at which an error occurred.
 func (q *Queryable) ExecMulti(commands ...*dat.Expression) (int, error) {
@@ -116,16 +330,20 @@ func (q *Queryable) ExecMulti(commands ...*dat.Expression) (int, error) {
 	return len(commands), nil
 }
 
-// InsertInto creates a new InsertBuilder for the given table.
+// InsertInto creates a new InsertBuilder for the given table. The builder
+// is bound to q.dialect, so a .Returning(...) call fails instead of
+// silently building invalid SQL against a dialect that doesn't support it.
 func (q *Queryable) InsertInto(table string) *dat.InsertBuilder {
-	b := dat.NewInsertBuilder(table)
+	b := dat.NewInsertBuilder(table, q.dialect)
 	b.Execer = NewExecer(q.runner, b)
 	return b
 }
 
-// Insect inserts or selects.
+// Insect inserts or selects. The builder is bound to q.dialect, so a
+// .Returning(...) call fails instead of silently building invalid SQL
+// against a dialect that doesn't support it.
 func (q *Queryable) Insect(table string) *dat.InsectBuilder {
-	b := dat.NewInsectBuilder(table)
+	b := dat.NewInsectBuilder(table, q.dialect)
 	b.Execer = NewExecer(q.runner, b)
 	return b
 }
@@ -133,14 +351,14 @@ func (q *Queryable) Insect(table string) *dat.InsectBuilder {
 // Select creates a new SelectBuilder for the given columns.
 func (q *Queryable) Select(columns ...string) *dat.SelectBuilder {
 	b := dat.NewSelectBuilder(columns...)
-	b.Execer = NewExecer(q.runner, b)
+	b.Execer = NewExecer(q.queryRunner(), b)
 	return b
 }
 
 // SelectDoc creates a new SelectBuilder for the given columns.
 func (q *Queryable) SelectDoc(columns ...string) *dat.SelectDocBuilder {
 	b := dat.NewSelectDocBuilder(columns...)
-	b.Execer = NewExecer(q.runner, b)
+	b.Execer = NewExecer(q.queryRunner(), b)
 	return b
 }
 
@@ -158,9 +376,12 @@ func (q *Queryable) Update(table string) *dat.UpdateBuilder {
 	return b
 }
 
-// Upsert creates a new UpdateBuilder for the given table.
+// Upsert creates a new UpdateBuilder for the given table. The builder is
+// bound to q.dialect, so it can gate its ON CONFLICT / ON DUPLICATE KEY
+// UPDATE SQL (or reject the call) per SupportsUpsert() instead of silently
+// generating invalid SQL against a dialect that doesn't support it.
 func (q *Queryable) Upsert(table string) *dat.UpsertBuilder {
-	b := dat.NewUpsertBuilder(table)
+	b := dat.NewUpsertBuilder(table, q.dialect)
 	b.Execer = NewExecer(q.runner, b)
 	return b
 }
@@ -0,0 +1,135 @@
+package runner
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// StmtCacheStats is a point-in-time snapshot of a statement cache's
+// hit/miss/eviction counters, surfaced through DB.StmtCacheStats so callers
+// can report them through the pluggable Logger or a metrics backend.
+type StmtCacheStats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+}
+
+// stmtCache is an LRU cache of prepared statements keyed by their
+// placeholder SQL template (i.e. before argument interpolation), so
+// repeated calls to the same Select(...).Where(...) shape reuse a single
+// *sqlx.Stmt instead of re-parsing it on every Exec.
+type stmtCache struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List // front = most recently used
+
+	hits      int64
+	misses    int64
+	evictions int64
+}
+
+type stmtCacheEntry struct {
+	key  string
+	stmt *sqlx.Stmt
+}
+
+// newStmtCache creates a statement cache holding at most capacity prepared
+// statements, evicting the least recently used entry once full.
+func newStmtCache(capacity int) *stmtCache {
+	return &stmtCache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// getOrPrepare returns the cached *sqlx.Stmt for sqlTemplate, preparing and
+// caching it against db if this is the first time it's been seen.
+func (c *stmtCache) getOrPrepare(db *sqlx.DB, sqlTemplate string) (*sqlx.Stmt, error) {
+	c.mu.Lock()
+	if el, ok := c.items[sqlTemplate]; ok {
+		c.order.MoveToFront(el)
+		c.hits++
+		stmt := el.Value.(*stmtCacheEntry).stmt
+		c.mu.Unlock()
+		return stmt, nil
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	stmt, err := db.Preparex(sqlTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[sqlTemplate]; ok {
+		// Lost a race with another goroutine that prepared the same
+		// statement first; keep theirs and close the redundant one.
+		c.order.MoveToFront(el)
+		stmt.Close()
+		return el.Value.(*stmtCacheEntry).stmt, nil
+	}
+
+	el := c.order.PushFront(&stmtCacheEntry{key: sqlTemplate, stmt: stmt})
+	c.items[sqlTemplate] = el
+	if c.order.Len() > c.capacity {
+		c.evictOldest()
+	}
+	return stmt, nil
+}
+
+// evictOldest removes the least recently used entry. Callers must hold c.mu.
+func (c *stmtCache) evictOldest() {
+	el := c.order.Back()
+	if el == nil {
+		return
+	}
+	entry := el.Value.(*stmtCacheEntry)
+	c.order.Remove(el)
+	delete(c.items, entry.key)
+	entry.stmt.Close()
+	c.evictions++
+}
+
+// invalidate drops a cache entry, used after an Exec against a cached
+// statement errors (the statement may be bound to a now-dead connection).
+func (c *stmtCache) invalidate(sqlTemplate string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[sqlTemplate]
+	if !ok {
+		return
+	}
+	entry := el.Value.(*stmtCacheEntry)
+	c.order.Remove(el)
+	delete(c.items, entry.key)
+	entry.stmt.Close()
+	c.evictions++
+}
+
+// Stats returns a snapshot of the cache's hit/miss/eviction counters.
+func (c *stmtCache) Stats() StmtCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return StmtCacheStats{Hits: c.hits, Misses: c.misses, Evictions: c.evictions}
+}
+
+// Close closes every cached statement and empties the cache.
+func (c *stmtCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var firstErr error
+	for el := c.order.Front(); el != nil; el = el.Next() {
+		if err := el.Value.(*stmtCacheEntry).stmt.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+	return firstErr
+}
@@ -0,0 +1,136 @@
+package runner
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// fakeDriver is a minimal database/sql driver that satisfies Preparex
+// without needing a real database, so the cache's LRU/counter/race-repair
+// logic can be tested in isolation.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) { return &fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type fakeStmt struct{}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) { return &fakeRows{}, nil }
+
+type fakeRows struct{}
+
+func (r *fakeRows) Columns() []string              { return nil }
+func (r *fakeRows) Close() error                   { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error { return io.EOF }
+
+var registerFakeDriverOnce sync.Once
+
+func newFakeSqlxDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	registerFakeDriverOnce.Do(func() {
+		sql.Register("stmtcache_fakedriver", fakeDriver{})
+	})
+	db, err := sql.Open("stmtcache_fakedriver", "")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	return sqlx.NewDb(db, "stmtcache_fakedriver")
+}
+
+func TestStmtCacheLRUEviction(t *testing.T) {
+	db := newFakeSqlxDB(t)
+	cache := newStmtCache(2)
+
+	if _, err := cache.getOrPrepare(db, "SELECT 1"); err != nil {
+		t.Fatalf("getOrPrepare(1): %v", err)
+	}
+	if _, err := cache.getOrPrepare(db, "SELECT 2"); err != nil {
+		t.Fatalf("getOrPrepare(2): %v", err)
+	}
+	// Touch "SELECT 1" so it becomes most recently used and "SELECT 2"
+	// is the one evicted once capacity is exceeded.
+	if _, err := cache.getOrPrepare(db, "SELECT 1"); err != nil {
+		t.Fatalf("getOrPrepare(1) again: %v", err)
+	}
+	if _, err := cache.getOrPrepare(db, "SELECT 3"); err != nil {
+		t.Fatalf("getOrPrepare(3): %v", err)
+	}
+
+	stats := cache.Stats()
+	if stats.Evictions != 1 {
+		t.Errorf("Evictions = %d, want 1", stats.Evictions)
+	}
+	if _, ok := cache.items["SELECT 2"]; ok {
+		t.Error("SELECT 2 should have been evicted as least recently used")
+	}
+	if _, ok := cache.items["SELECT 1"]; !ok {
+		t.Error("SELECT 1 should still be cached")
+	}
+	if _, ok := cache.items["SELECT 3"]; !ok {
+		t.Error("SELECT 3 should be cached")
+	}
+}
+
+func TestStmtCacheHitMissCounters(t *testing.T) {
+	db := newFakeSqlxDB(t)
+	cache := newStmtCache(4)
+
+	if _, err := cache.getOrPrepare(db, "SELECT 1"); err != nil {
+		t.Fatalf("getOrPrepare: %v", err)
+	}
+	if _, err := cache.getOrPrepare(db, "SELECT 1"); err != nil {
+		t.Fatalf("getOrPrepare: %v", err)
+	}
+	if _, err := cache.getOrPrepare(db, "SELECT 1"); err != nil {
+		t.Fatalf("getOrPrepare: %v", err)
+	}
+
+	stats := cache.Stats()
+	if stats.Misses != 1 {
+		t.Errorf("Misses = %d, want 1", stats.Misses)
+	}
+	if stats.Hits != 2 {
+		t.Errorf("Hits = %d, want 2", stats.Hits)
+	}
+}
+
+func TestStmtCacheConcurrentGetOrPrepareSharesOneEntry(t *testing.T) {
+	db := newFakeSqlxDB(t)
+	cache := newStmtCache(4)
+
+	const goroutines = 32
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			if _, err := cache.getOrPrepare(db, "SELECT 1"); err != nil {
+				t.Errorf("getOrPrepare: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := len(cache.items); got != 1 {
+		t.Errorf("len(items) = %d, want 1 (concurrent prepares of the same query must collapse to one entry)", got)
+	}
+	if cache.order.Len() != 1 {
+		t.Errorf("order.Len() = %d, want 1", cache.order.Len())
+	}
+}
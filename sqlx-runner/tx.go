@@ -1,7 +1,9 @@
 package runner
 
 import (
-	"log"
+	"context"
+	"database/sql"
+	"fmt"
 	"sync"
 	"time"
 
@@ -22,6 +24,13 @@ const (
 // transaction that has already been rollbacked.
 var ErrTxRollbacked = errors.New("Nested transaction already rollbacked")
 
+// txFrame captures the state a nested Begin() needs to restore once the
+// nested scope it opened is closed.
+type txFrame struct {
+	state     int
+	savepoint string
+}
+
 // Tx is a transaction abstraction
 type Tx struct {
 	sync.Mutex
@@ -29,12 +38,23 @@ type Tx struct {
 	*Queryable
 	IsRollbacked bool
 	state        int
-	stateStack   []int
+	// savepoint is the name of the Postgres savepoint protecting the current
+	// nesting level, empty at the outermost level.
+	savepoint    string
+	savepointSeq int
+	stateStack   []txFrame
 }
 
-// WrapSqlxTx creates a Tx from a sqlx.Tx
+// WrapSqlxTx creates a Tx from a sqlx.Tx, logging through defaultLogger.
+// Prefer DB.Begin/BeginTxContext when a *DB is available so the Tx inherits
+// its logger and slow-query threshold.
 func WrapSqlxTx(tx *sqlx.Tx) *Tx {
-	newtx := &Tx{Tx: tx, Queryable: &Queryable{tx}}
+	return newTx(tx, &Queryable{runner: tx, logger: defaultLogger, dialect: postgresDialect{}})
+}
+
+func newTx(sqlxTx *sqlx.Tx, q *Queryable) *Tx {
+	q.runner = sqlxTx
+	newtx := &Tx{Tx: sqlxTx, Queryable: q}
 	if dat.Strict {
 		time.AfterFunc(1*time.Minute, func() {
 			if !newtx.IsRollbacked && newtx.state == txPending {
@@ -47,18 +67,35 @@ func WrapSqlxTx(tx *sqlx.Tx) *Tx {
 
 // Begin creates a transaction for the given database
 func (db *DB) Begin() (*Tx, error) {
-	tx, err := db.DB.Beginx()
+	sqlxTx, err := db.DB.Beginx()
 	if err != nil {
 		if dat.Strict {
-			Logger.Fatal("Could not create transaction")
+			db.logger.Fatal("Could not create transaction")
 		}
-		return nil, Logger.Error("begin.error", err)
+		return nil, db.logger.Error("begin.error", "err", err)
 	}
-	Logger.Debug("begin tx")
-	return WrapSqlxTx(tx), nil
+	db.logger.Debug("begin tx")
+	return newTx(sqlxTx, &Queryable{logger: db.logger, logQueriesThreshold: db.logQueriesThreshold, dialect: db.dialect}), nil
 }
 
-// Begin returns this transaction
+// BeginTxContext creates a transaction for the given database, honoring ctx
+// cancellation/deadline and the supplied *sql.TxOptions. Use this instead of
+// Begin when callers need per-request deadlines or isolation level control.
+func (db *DB) BeginTxContext(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	sqlxTx, err := db.DB.BeginTxx(ctx, opts)
+	if err != nil {
+		if dat.Strict {
+			db.logger.Fatal("Could not create transaction")
+		}
+		return nil, db.logger.Error("begin.error", "err", err)
+	}
+	db.logger.Debug("begin tx")
+	return newTx(sqlxTx, &Queryable{logger: db.logger, logQueriesThreshold: db.logQueriesThreshold, dialect: db.dialect}), nil
+}
+
+// Begin opens a nested scope backed by a real Postgres SAVEPOINT, so that a
+// Rollback (or AutoRollback) of the nested scope only undoes the work done
+// since this call, leaving the outer transaction alive.
 func (tx *Tx) Begin() (*Tx, error) {
 	tx.Lock()
 	defer tx.Unlock()
@@ -66,62 +103,88 @@ func (tx *Tx) Begin() (*Tx, error) {
 		return nil, ErrTxRollbacked
 	}
 
-	Logger.Debug("begin nested tx")
-	tx.pushState()
+	name := tx.nextSavepointName()
+	if _, err := tx.Tx.Exec("SAVEPOINT " + name); err != nil {
+		return nil, tx.logger.Error("begin.savepoint.error", "err", err)
+	}
+
+	tx.logger.Debug("begin nested tx", "savepoint", name)
+	tx.pushState(name)
 	return tx, nil
 }
 
-// Commit commits the transaction
+// Commit commits the transaction, or releases the current savepoint if this
+// is a nested scope opened by Begin.
 func (tx *Tx) Commit() error {
 	tx.Lock()
 	defer tx.Unlock()
 
 	if tx.IsRollbacked {
-		return Logger.Error("Cannot commit", ErrTxRollbacked)
+		return tx.logger.Error("Cannot commit", "err", ErrTxRollbacked)
 	}
 
 	if tx.state == txCommitted {
-		return Logger.Error("Transaction has already been commited")
+		return tx.logger.Error("Transaction has already been commited")
 	}
 	if tx.state == txRollbacked {
-		return Logger.Error("Transaction has already been rollbacked")
+		return tx.logger.Error("Transaction has already been rollbacked")
 	}
 
-	if len(tx.stateStack) == 0 {
-		err := tx.Tx.Commit()
-		if err != nil {
+	if tx.savepoint != "" {
+		if _, err := tx.Tx.Exec("RELEASE SAVEPOINT " + tx.savepoint); err != nil {
 			tx.state = txErred
-			return Logger.Error("commit.error", err)
+			tx.popState()
+			return tx.logger.Error("commit.savepoint.error", "err", err)
 		}
+	} else if err := tx.Tx.Commit(); err != nil {
+		tx.state = txErred
+		tx.popState()
+		return tx.logger.Error("commit.error", "err", err)
 	}
 
-	Logger.Debug("commit")
+	tx.logger.Debug("commit")
 	tx.state = txCommitted
+	tx.popState()
 	return nil
 }
 
-// Rollback cancels the transaction
+// Rollback cancels the transaction, or rolls back to the current savepoint
+// if this is a nested scope opened by Begin, leaving the outer transaction
+// alive. Only an outermost Rollback sets IsRollbacked.
 func (tx *Tx) Rollback() error {
 	tx.Lock()
 	defer tx.Unlock()
 
 	if tx.IsRollbacked {
-		return Logger.Error("Cannot rollback", ErrTxRollbacked)
+		return tx.logger.Error("Cannot rollback", "err", ErrTxRollbacked)
 	}
 	if tx.state == txCommitted {
-		return Logger.Error("Cannot rollback, transaction has already been commited")
+		return tx.logger.Error("Cannot rollback, transaction has already been commited")
+	}
+
+	if tx.savepoint != "" {
+		if _, err := tx.Tx.Exec("ROLLBACK TO SAVEPOINT " + tx.savepoint); err != nil {
+			tx.state = txErred
+			tx.popState()
+			return tx.logger.Error("Unable to rollback to savepoint", "err", err)
+		}
+		tx.logger.Debug("rollback to savepoint", "savepoint", tx.savepoint)
+		tx.state = txRollbacked
+		tx.popState()
+		return nil
 	}
 
-	// rollback is sent to the database even in nested state
 	err := tx.Tx.Rollback()
 	if err != nil {
 		tx.state = txErred
-		return Logger.Error("Unable to rollback", "err", err)
+		tx.popState()
+		return tx.logger.Error("Unable to rollback", "err", err)
 	}
 
-	Logger.Debug("rollback")
+	tx.logger.Debug("rollback")
 	tx.state = txRollbacked
 	tx.IsRollbacked = true
+	tx.popState()
 	return nil
 }
 
@@ -135,16 +198,21 @@ func (tx *Tx) AutoCommit() error {
 		return nil
 	}
 
-	err := tx.Tx.Commit()
+	var err error
+	if tx.savepoint != "" {
+		_, err = tx.Tx.Exec("RELEASE SAVEPOINT " + tx.savepoint)
+	} else {
+		err = tx.Tx.Commit()
+	}
 	if err != nil {
 		tx.state = txErred
 		if dat.Strict {
-			log.Fatalf("Could not commit transaction: %s\n", err.Error())
+			tx.logger.Fatal("Could not commit transaction", "err", err)
 		}
 		tx.popState()
-		return Logger.Error("transaction.AutoCommit.commit_error", err)
+		return tx.logger.Error("transaction.AutoCommit.commit_error", "err", err)
 	}
-	Logger.Debug("autocommit")
+	tx.logger.Debug("autocommit")
 	tx.state = txCommitted
 	tx.popState()
 	return err
@@ -160,31 +228,87 @@ func (tx *Tx) AutoRollback() error {
 		return nil
 	}
 
-	err := tx.Tx.Rollback()
+	nested := tx.savepoint != ""
+	var err error
+	if nested {
+		_, err = tx.Tx.Exec("ROLLBACK TO SAVEPOINT " + tx.savepoint)
+	} else {
+		err = tx.Tx.Rollback()
+	}
 	if err != nil {
 		tx.state = txErred
 		if dat.Strict {
-			log.Fatalf("Could not rollback transaction: %s\n", err.Error())
+			tx.logger.Fatal("Could not rollback transaction", "err", err)
 		}
 		tx.popState()
-		return Logger.Error("transaction.AutoRollback.rollback_error", err)
+		return tx.logger.Error("transaction.AutoRollback.rollback_error", "err", err)
 	}
-	Logger.Debug("autorollback")
+	tx.logger.Debug("autorollback")
 	tx.state = txRollbacked
-	tx.IsRollbacked = true
+	if !nested {
+		tx.IsRollbacked = true
+	}
 	tx.popState()
 	return err
 }
 
+// Savepoint creates a named Postgres savepoint, independent of the nesting
+// stack Begin/AutoRollback maintain, for callers that want to roll back to
+// an explicit point without opening a full nested scope.
+func (tx *Tx) Savepoint(name string) error {
+	tx.Lock()
+	defer tx.Unlock()
+	if tx.IsRollbacked {
+		return ErrTxRollbacked
+	}
+
+	if _, err := tx.Tx.Exec("SAVEPOINT " + name); err != nil {
+		return tx.logger.Error("savepoint.error", "err", err)
+	}
+	tx.logger.Debug("savepoint", "savepoint", name)
+	return nil
+}
+
+// RollbackTo rolls the transaction back to a savepoint previously created
+// with Savepoint (or Begin's internal savepoint name), without discarding
+// the rest of the transaction.
+func (tx *Tx) RollbackTo(name string) error {
+	tx.Lock()
+	defer tx.Unlock()
+	if tx.IsRollbacked {
+		return ErrTxRollbacked
+	}
+
+	if _, err := tx.Tx.Exec("ROLLBACK TO SAVEPOINT " + name); err != nil {
+		return tx.logger.Error("rollback_to.error", "err", err)
+	}
+	tx.logger.Debug("rollback to savepoint", "savepoint", name)
+	return nil
+}
+
 // Select creates a new SelectBuilder for the given columns.
 // This disambiguates between Queryable.Select and sqlx's Select
 func (tx *Tx) Select(columns ...string) *dat.SelectBuilder {
 	return tx.Queryable.Select(columns...)
 }
 
-func (tx *Tx) pushState() {
-	tx.stateStack = append(tx.stateStack, tx.state)
+// SelectContext creates a new SelectBuilder for the given columns.
+// This disambiguates between Queryable.SelectContext and sqlx.Tx's SelectContext
+func (tx *Tx) SelectContext(ctx context.Context, columns ...string) *dat.SelectBuilder {
+	return tx.Queryable.SelectContext(ctx, columns...)
+}
+
+// nextSavepointName generates a unique savepoint name for this Tx. Callers
+// must hold tx.Mutex.
+func (tx *Tx) nextSavepointName() string {
+	tx.savepointSeq++
+	return fmt.Sprintf("sp_%d", tx.savepointSeq)
+}
+
+func (tx *Tx) pushState(savepoint string) {
+	tx.stateStack = append(tx.stateStack, txFrame{state: tx.state, savepoint: tx.savepoint})
 	tx.state = txPending
+	tx.savepoint = savepoint
 }
 
 func (tx *Tx) popState() {
@@ -192,7 +316,8 @@ func (tx *Tx) popState() {
 		return
 	}
 
-	var val int
-	val, tx.stateStack = tx.stateStack[len(tx.stateStack)-1], tx.stateStack[:len(tx.stateStack)-1]
-	tx.state = val
+	var frame txFrame
+	frame, tx.stateStack = tx.stateStack[len(tx.stateStack)-1], tx.stateStack[:len(tx.stateStack)-1]
+	tx.state = frame.state
+	tx.savepoint = frame.savepoint
 }
@@ -0,0 +1,65 @@
+package runner
+
+import "testing"
+
+func TestTxPushPopStateRestoresPriorFrame(t *testing.T) {
+	tx := &Tx{}
+
+	tx.state = txPending
+	tx.savepoint = ""
+
+	tx.pushState("sp_1")
+	if tx.state != txPending {
+		t.Fatalf("state after pushState = %d, want txPending", tx.state)
+	}
+	if tx.savepoint != "sp_1" {
+		t.Fatalf("savepoint after pushState = %q, want %q", tx.savepoint, "sp_1")
+	}
+
+	tx.state = txCommitted
+	tx.pushState("sp_2")
+	if tx.savepoint != "sp_2" {
+		t.Fatalf("savepoint after nested pushState = %q, want %q", tx.savepoint, "sp_2")
+	}
+
+	tx.state = txRollbacked
+	tx.popState()
+	if tx.state != txCommitted {
+		t.Errorf("state after first popState = %d, want txCommitted", tx.state)
+	}
+	if tx.savepoint != "sp_1" {
+		t.Errorf("savepoint after first popState = %q, want %q", tx.savepoint, "sp_1")
+	}
+
+	tx.popState()
+	if tx.state != txPending {
+		t.Errorf("state after second popState = %d, want txPending", tx.state)
+	}
+	if tx.savepoint != "" {
+		t.Errorf("savepoint after second popState = %q, want empty", tx.savepoint)
+	}
+}
+
+func TestTxPopStateOnEmptyStackIsNoop(t *testing.T) {
+	tx := &Tx{state: txCommitted, savepoint: "sp_outer"}
+
+	tx.popState()
+
+	if tx.state != txCommitted {
+		t.Errorf("state = %d, want txCommitted (popState on an empty stack must not change it)", tx.state)
+	}
+	if tx.savepoint != "sp_outer" {
+		t.Errorf("savepoint = %q, want %q", tx.savepoint, "sp_outer")
+	}
+}
+
+func TestTxNextSavepointNameIsUniquePerTx(t *testing.T) {
+	tx := &Tx{}
+
+	first := tx.nextSavepointName()
+	second := tx.nextSavepointName()
+
+	if first == second {
+		t.Errorf("nextSavepointName returned %q twice", first)
+	}
+}